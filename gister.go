@@ -1,8 +1,9 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
@@ -12,17 +13,23 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/atotto/clipboard"
 	"github.com/gofrs/uuid"
+	"github.com/google/go-github/v66/github"
 	"github.com/leaf-ai/studio-go-runner/pkg/studio"
+	"github.com/zalando/go-keyring"
+	"golang.org/x/oauth2"
 
 	"github.com/karlmutch/errors"
 	"github.com/karlmutch/stack"
 )
 
 const (
-	// Version defines the app version
-	VERSION = "v0.4.0"
+	// VERSION is bumped on every user-visible change, including bug fixes,
+	// not just new features.
+	VERSION = "v0.6.3"
 
 	USER_AGENT = "gister/" + VERSION
 )
@@ -30,49 +37,185 @@ const (
 var (
 	logger = studio.NewLogger("gister")
 
-	public      bool
-	description string
-	anonymous   bool
-	update      string
-	responseObj map[string]interface{}
+	// commands lists the recognised subcommands, used to tell a subcommand
+	// apart from the "gister file.go" create shorthand.
+	commands = map[string]bool{
+		"create":  true,
+		"update":  true,
+		"get":     true,
+		"list":    true,
+		"delete":  true,
+		"star":    true,
+		"unstar":  true,
+		"fork":    true,
+		"comment": true,
+		"login":   true,
+	}
 )
 
-// The top-level struct for a gist file
-type GistFile struct {
-	Content string `json:"content"`
-}
+const (
+	keyringService = "gister"
+	keyringUser    = "github-token"
+)
 
-// The required structure for POST data for API purposes
-type Gist struct {
-	Description string              `json:"description,omitempty"`
-	Public      bool                `json:"public"`
-	GistFile    map[string]GistFile `json:"files"`
+// readConfigLines reads the non-blank, trimmed lines of the '$HOME/.gist'
+// config file. Line 1 is the GISTER_GITHUB_TOKEN; line 2, if present, is
+// either a bare GitHub Enterprise API URL or an "endpoint=<url>" entry.
+func readConfigLines() (lines []string, err errors.Error) {
+	file := filepath.Join(os.Getenv("HOME"), ".gist")
+	data, errGo := ioutil.ReadFile(file)
+	if errGo != nil {
+		return nil, errors.Wrap(errGo).With("file", file).With("stack", stack.Trace().TrimRuntime())
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); len(line) != 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
 }
 
-// loadTokenFromFile loads the GISTER_GITHUB_TOKEN from a '$HOME/.gist' file
-// from the user's home directory.
+// loadToken resolves the GitHub credential to authenticate with, in order:
+// the GISTER_GITHUB_TOKEN environment variable, the OS keyring, then the
+// first line of the legacy '$HOME/.gist' file. The result is either a plain
+// PAT, or a "username:token" pair for one release cycle of backward
+// compatibility with the retired basic-auth flow.
 func loadToken() (token string, err errors.Error) {
-	// GISTER_GITHUB_TOKEN must be in format of `username:token`
 	if token = os.Getenv("GISTER_GITHUB_TOKEN"); len(token) != 0 {
 		return token, nil
 	}
 
-	// Fall back to attempting to read from the config file
+	if stored, errGo := keyring.Get(keyringService, keyringUser); errGo == nil {
+		return stored, nil
+	} else if !stderrors.Is(errGo, keyring.ErrNotFound) {
+		logger.Debug("keyring unavailable: " + errGo.Error())
+	}
+
+	lines, err := readConfigLines()
+	if err != nil {
+		return "", err
+	}
+	if len(lines) == 0 {
+		return "", errors.Wrap(fmt.Errorf("config file contained no token")).With("stack", stack.Trace().TrimRuntime())
+	}
+	return lines[0], nil
+}
+
+// storeToken persists a freshly obtained PAT in the OS keyring, falling back
+// to the legacy '$HOME/.gist' file with 0600 permissions when no keyring
+// provider is available. Any existing endpoint line in the config file is
+// preserved.
+func storeToken(token string) (err errors.Error) {
+	if errGo := keyring.Set(keyringService, keyringUser, token); errGo == nil {
+		return nil
+	}
+
+	lines, _ := readConfigLines()
+	contents := token
+	if len(lines) >= 2 {
+		contents += "\n" + lines[1]
+	}
+
 	file := filepath.Join(os.Getenv("HOME"), ".gist")
-	github_token, errGo := ioutil.ReadFile(file)
-	if errGo != nil {
-		return "", errors.Wrap(errGo).With("file", file).With("stack", stack.Trace().TrimRuntime())
+	if errGo := ioutil.WriteFile(file, []byte(contents+"\n"), 0600); errGo != nil {
+		return errors.Wrap(errGo).With("file", file).With("stack", stack.Trace().TrimRuntime())
 	}
-	return strings.TrimSpace(string(github_token)), nil
+	return nil
 }
 
-func getGist(names []string) (gist *Gist, err errors.Error) {
+// parseEndpointLines picks the endpoint override, if any, out of the config
+// file lines that follow the token on line 1: either an "endpoint=<url>"
+// entry, or a bare URL.
+func parseEndpointLines(lines []string) (endpoint string) {
+	for _, line := range lines {
+		if strings.HasPrefix(line, "endpoint=") {
+			return strings.TrimPrefix(line, "endpoint=")
+		}
+	}
+	if len(lines) >= 1 && !strings.Contains(lines[0], "=") {
+		return lines[0]
+	}
+	return ""
+}
+
+// loadEndpoint resolves the GitHub Enterprise API base URL, if any, from the
+// GISTER_GITHUB_API_URL environment variable or the second line of the
+// '$HOME/.gist' config file, mirroring the way loadToken resolves the token.
+// An empty result means the public api.github.com endpoint should be used.
+func loadEndpoint() (endpoint string, err errors.Error) {
+	if endpoint = os.Getenv("GISTER_GITHUB_API_URL"); len(endpoint) != 0 {
+		return endpoint, nil
+	}
 
-	// create a gist from the files array
-	gist = &Gist{
-		Description: strings.Join(flag.Args(), ", "),
-		Public:      false,
-		GistFile:    map[string]GistFile{},
+	lines, errL := readConfigLines()
+	if errL != nil || len(lines) < 2 {
+		// No config file, or no second line, just means no endpoint override.
+		return "", nil
+	}
+	return parseEndpointLines(lines[1:]), nil
+}
+
+// newClient constructs a go-github client, authenticated unless anonymous is
+// requested, and pointed at a GitHub Enterprise Server when endpoint is set.
+func newClient(ctx context.Context, anonymous bool, endpoint string) (client *github.Client, err errors.Error) {
+	var httpClient *http.Client
+
+	if !anonymous {
+		token, err := loadToken()
+		if err != nil {
+			return nil, err
+		}
+
+		// A colon marks the legacy "username:token" basic-auth format, kept
+		// working for one release cycle; anything else is a plain PAT sent
+		// as a bearer token, matching GitHub's retirement of basic auth.
+		if words := strings.SplitN(token, ":", 2); len(words) == 2 {
+			httpClient = &http.Client{Transport: &basicAuthTransport{username: words[0], password: words[1]}}
+		} else {
+			ts := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token})
+			httpClient = oauth2.NewClient(ctx, ts)
+		}
+	}
+
+	if len(endpoint) == 0 {
+		if endpoint, err = loadEndpoint(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(endpoint) != 0 {
+		client, errGo := github.NewEnterpriseClient(endpoint, endpoint, httpClient)
+		if errGo != nil {
+			return nil, errors.Wrap(errGo).With("endpoint", endpoint).With("stack", stack.Trace().TrimRuntime())
+		}
+		return client, nil
+	}
+
+	return github.NewClient(httpClient), nil
+}
+
+// buildFiles reads the named files, standard input, or the OS clipboard and
+// assembles them into the map of gist files expected by the GitHub API.
+func buildFiles(names []string, useClipboard bool, clipboardName string) (files map[github.GistFilename]github.GistFile, err errors.Error) {
+
+	files = map[github.GistFilename]github.GistFile{}
+
+	if useClipboard {
+		logger.Debug("Reading clipboard")
+		content, errGo := clipboard.ReadAll()
+		if errGo != nil {
+			return nil, errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime())
+		}
+		name := clipboardName
+		if len(name) == 0 {
+			uu, errGo := uuid.NewV4()
+			if errGo != nil {
+				return nil, errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime())
+			}
+			name = uu.String()
+		}
+		files[github.GistFilename(name)] = github.GistFile{Content: &content}
+		return files, nil
 	}
 
 	for _, filename := range names {
@@ -87,7 +230,8 @@ func getGist(names []string) (gist *Gist, err errors.Error) {
 			if errGo != nil {
 				return nil, errors.Wrap(errGo).With("file", "-").With("stack", stack.Trace().TrimRuntime())
 			}
-			gist.GistFile[uu.String()] = GistFile{string(content)}
+			body := string(content)
+			files[github.GistFilename(uu.String())] = github.GistFile{Content: &body}
 			continue
 		}
 
@@ -96,111 +240,577 @@ func getGist(names []string) (gist *Gist, err errors.Error) {
 		if errGo != nil {
 			return nil, errors.Wrap(errGo).With("file", filename).With("stack", stack.Trace().TrimRuntime())
 		}
-		gist.GistFile[filepath.Base(filename)] = GistFile{string(content)}
+		body := string(content)
+		files[github.GistFilename(filepath.Base(filename))] = github.GistFile{Content: &body}
 	}
 
-	return gist, nil
+	return files, nil
+}
+
+// basicAuthTransport sends requests using the retired username:password
+// basic-auth scheme, for the one release cycle it is still supported.
+type basicAuthTransport struct {
+	username string
+	password string
 }
 
-// Defines basic usage when program is run with the help flag
-func usage() {
-	fmt.Fprintf(os.Stderr, "usage: gist [options] <file>|-\n")
-	flag.PrintDefaults()
+func (t *basicAuthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.SetBasicAuth(t.username, t.password)
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// addEndpointFlag registers the -e/--endpoint flag shared by every
+// subcommand that talks to the GitHub API, returning a pointer to its value.
+func addEndpointFlag(fs *flag.FlagSet) *string {
+	endpoint := new(string)
+	usage := "GitHub Enterprise API base URL, e.g. https://ghe.example.com/api/v3/ (or GISTER_GITHUB_API_URL)."
+	fs.StringVar(endpoint, "e", "", usage)
+	fs.StringVar(endpoint, "endpoint", "", usage)
+	return endpoint
+}
+
+// reportGithubErr surfaces a go-github error, unwrapping the typed
+// *github.ErrorResponse so that validation failures and rate limiting are
+// reported individually rather than as an opaque failure.
+func reportGithubErr(errGo error, url string) {
+	var rateErr *github.RateLimitError
+	if stderrors.As(errGo, &rateErr) {
+		logger.Fatal(fmt.Sprintf("rate limited until %s", rateErr.Rate.Reset.String()), "url", url)
+	}
+
+	var ghErr *github.ErrorResponse
+	if stderrors.As(errGo, &ghErr) {
+		for i, fieldErr := range ghErr.Errors {
+			logger.Error(fmt.Sprintf("%d %s %s: %s", i, fieldErr.Resource, fieldErr.Field, fieldErr.Code))
+		}
+		logger.Fatal(ghErr.Message, "url", url)
+	}
+
+	logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
+}
+
+// Defines basic usage when program is run with the help flag. fs is the
+// subcommand's flag.FlagSet, whose registered flags are printed alongside
+// the command list; it is nil when no subcommand has been parsed yet.
+func usage(fs *flag.FlagSet) {
+	fmt.Fprintf(os.Stderr, `usage: gister [options] <file>|-|-c       (shorthand for create)
+       gister create [options] <file>|-|-c
+       gister update [options] <gist-id> <file>|-|-c
+       gister get <gist-id>
+       gister list [username]
+       gister delete <gist-id>
+       gister star [-check] <gist-id>
+       gister unstar <gist-id>
+       gister fork <gist-id>
+       gister comment [-list] <gist-id> [text]
+       gister login
+
+Every subcommand except login accepts -e/--endpoint (or GISTER_GITHUB_API_URL)
+to target a GitHub Enterprise Server instead of api.github.com; login always
+uses github.com.
+`)
+	if fs != nil {
+		fs.PrintDefaults()
+	}
 	os.Exit(2)
 }
 
-// The main function parses the CLI args. It also checks the files, and
-// loads them into an array.
-// Then the files are separated into GistFile structs and collectively
-// the files are saved in `files` field in the Gist struct.
-// A request is then made to the GitHub api - it depends on whether it is
-// anonymous gist or not.
-// The response recieved is parsed and the Gist URL is printed to STDOUT.
 func main() {
-	flag.StringVar(&update, "u", "", "id of existing gist to update")
-	flag.BoolVar(&public, "p", false, "Set to true for public gist.")
-	flag.BoolVar(&anonymous, "a", false, "Set to true for anonymous gist user")
-	flag.StringVar(&description, "d", "", "Description for gist.")
-	flag.Usage = usage
-	flag.Parse()
-
-	fileNames := flag.Args()
-	if len(fileNames) == 0 {
+	args := os.Args[1:]
+	if len(args) == 0 {
+		usage(nil)
+	}
+
+	cmd, rest := "create", args
+	if commands[args[0]] {
+		cmd, rest = args[0], args[1:]
+	}
+
+	ctx := context.Background()
+
+	switch cmd {
+	case "create":
+		cmdCreate(ctx, rest)
+	case "update":
+		cmdUpdate(ctx, rest)
+	case "get":
+		cmdGet(ctx, rest)
+	case "list":
+		cmdList(ctx, rest)
+	case "delete":
+		cmdDelete(ctx, rest)
+	case "star":
+		cmdStar(ctx, rest)
+	case "unstar":
+		cmdUnstar(ctx, rest)
+	case "fork":
+		cmdFork(ctx, rest)
+	case "comment":
+		cmdComment(ctx, rest)
+	case "login":
+		cmdLogin(ctx, rest)
+	default:
+		usage(nil)
+	}
+}
+
+// cmdCreate implements "gister create", and is also what the bare
+// "gister <file>" shorthand runs.
+func cmdCreate(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("create", flag.ExitOnError)
+	public := fs.Bool("p", false, "Set to true for public gist.")
+	anonymous := fs.Bool("a", false, "Set to true for anonymous gist user")
+	description := fs.String("d", "", "Description for gist.")
+	useClipboard := fs.Bool("c", false, "Read gist content from the OS clipboard, instead of file(s) or stdin.")
+	clipboardName := fs.String("f", "", "Filename to use for the clipboard content, defaults to a generated UUID (only used with -c).")
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	fileNames := fs.Args()
+	if *useClipboard {
+		if len(fileNames) != 0 {
+			log.Fatal("Error: -c cannot be combined with file arguments or standard input.")
+		}
+	} else if len(fileNames) == 0 {
 		log.Fatal("Error: No input file(s), or standard input specified.")
 	}
 
-	gist, err := getGist(fileNames)
+	files, err := buildFiles(fileNames, *useClipboard, *clipboardName)
 	if err != nil {
 		logger.Fatal(err.Error())
 	}
 
-	// Override defaults with the command line specified values, if they are not empty in the
-	// case of the description
-	if len(description) != 0 {
-		gist.Description = description
+	gist := &github.Gist{
+		Description: github.String(*description),
+		Public:      github.Bool(*public),
+		Files:       files,
 	}
-	gist.Public = public
 
-	pfile, errGo := json.Marshal(*gist)
+	client, err := newClient(ctx, *anonymous, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	result, _, errGo := client.Gists.Create(ctx, gist)
 	if errGo != nil {
-		logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
+		reportGithubErr(errGo, "gists")
+		return
+	}
+	fmt.Println(result.GetHTMLURL())
+}
+
+// cmdUpdate implements "gister update <gist-id> <file>|-|-c".
+func cmdUpdate(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	public := fs.Bool("p", false, "Set to true for public gist, false for private; leave unset to keep the gist's current visibility.")
+	anonymous := fs.Bool("a", false, "Set to true for anonymous gist user")
+	description := fs.String("d", "", "Description for gist.")
+	useClipboard := fs.Bool("c", false, "Read gist content from the OS clipboard, instead of file(s) or stdin.")
+	clipboardName := fs.String("f", "", "Filename to use for the clipboard content, defaults to a generated UUID (only used with -c).")
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		log.Fatal("Error: gist id is required, e.g. gister update <gist-id> <file>|-|-c.")
+	}
+	id, fileNames := rest[0], rest[1:]
+
+	if *useClipboard {
+		if len(fileNames) != 0 {
+			log.Fatal("Error: -c cannot be combined with file arguments or standard input.")
+		}
+	} else if len(fileNames) == 0 {
+		log.Fatal("Error: No input file(s), or standard input specified.")
+	}
+
+	files, err := buildFiles(fileNames, *useClipboard, *clipboardName)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	gist := &github.Gist{
+		Files: files,
+	}
+	if len(*description) != 0 {
+		gist.Description = github.String(*description)
+	}
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == "p" {
+			gist.Public = github.Bool(*public)
+		}
+	})
+
+	client, err := newClient(ctx, *anonymous, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
 	}
 
-	// Send request to API
-	base, errGo := url.Parse("https://api.github.com/")
+	result, _, errGo := client.Gists.Edit(ctx, id, gist)
 	if errGo != nil {
-		logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
+		reportGithubErr(errGo, "gists/"+id)
+		return
+	}
+	fmt.Println(result.GetHTMLURL())
+}
+
+// cmdGet implements "gister get <gist-id>", printing the content of every
+// file in the gist to stdout.
+func cmdGet(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("get", flag.ExitOnError)
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		log.Fatal("Error: usage is gister get <gist-id>.")
 	}
+	id := rest[0]
 
-	postTo := "gists"
-	if update != "" {
-		postTo += "/" + update
+	client, err := newClient(ctx, false, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
 	}
-	urlPath, errGo := url.Parse(postTo)
+
+	gist, _, errGo := client.Gists.Get(ctx, id)
 	if errGo != nil {
-		logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
+		reportGithubErr(errGo, "gists/"+id)
+		return
+	}
+
+	for name, file := range gist.Files {
+		fmt.Printf("### %s\n%s\n", name, file.GetContent())
+	}
+}
+
+// listAnonymous reports whether "gister list" should use an unauthenticated
+// client: go-github's Gists.List hits /gists (the authenticated caller's own
+// gists) when no username is given, and /users/{user}/gists (that user's
+// public gists, no auth required) when one is. So a client is only
+// anonymous when a username was given.
+func listAnonymous(rest []string) bool {
+	return len(rest) != 0
+}
+
+// cmdList implements "gister list [username]", listing gists for the
+// named user, or the authenticated user when no username is given.
+func cmdList(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("list", flag.ExitOnError)
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) > 1 {
+		log.Fatal("Error: usage is gister list [username].")
+	}
+
+	client, err := newClient(ctx, listAnonymous(rest), *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	user := ""
+	if len(rest) == 1 {
+		user = rest[0]
 	}
 
-	req, errGo := http.NewRequest("POST", base.ResolveReference(urlPath).String(), bytes.NewBuffer(pfile))
+	gists, _, errGo := client.Gists.List(ctx, user, nil)
 	if errGo != nil {
-		logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
+		reportGithubErr(errGo, "gists")
+		return
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("User-Agent", USER_AGENT)
-	if !anonymous {
-		token, err := loadToken()
-		if err != nil {
-			logger.Fatal(err.Error())
+	for _, gist := range gists {
+		fmt.Printf("%s\t%s\t%s\n", gist.GetID(), gist.GetDescription(), gist.GetHTMLURL())
+	}
+}
+
+// cmdDelete implements "gister delete <gist-id>".
+func cmdDelete(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("delete", flag.ExitOnError)
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		log.Fatal("Error: usage is gister delete <gist-id>.")
+	}
+	id := rest[0]
+
+	client, err := newClient(ctx, false, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	if _, errGo := client.Gists.Delete(ctx, id); errGo != nil {
+		reportGithubErr(errGo, "gists/"+id)
+		return
+	}
+}
+
+// cmdStar implements "gister star [-check] <gist-id>". With -check it only
+// reports whether the gist is already starred, using IsStarred, rather than
+// modifying anything.
+func cmdStar(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("star", flag.ExitOnError)
+	check := fs.Bool("check", false, "Only report whether the gist is starred, do not star it.")
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		log.Fatal("Error: usage is gister star [-check] <gist-id>.")
+	}
+	id := rest[0]
+
+	client, err := newClient(ctx, false, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	if *check {
+		starred, _, errGo := client.Gists.IsStarred(ctx, id)
+		if errGo != nil {
+			reportGithubErr(errGo, "gists/"+id+"/star")
+			return
 		}
-		words := strings.Split(token, ":")
-		if len(words) != 2 {
-			log.Fatalf("token must be in form 'username:token'")
+		fmt.Println(starred)
+		return
+	}
+
+	if _, errGo := client.Gists.Star(ctx, id); errGo != nil {
+		reportGithubErr(errGo, "gists/"+id+"/star")
+		return
+	}
+}
+
+// cmdUnstar implements "gister unstar <gist-id>".
+func cmdUnstar(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("unstar", flag.ExitOnError)
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		log.Fatal("Error: usage is gister unstar <gist-id>.")
+	}
+	id := rest[0]
+
+	client, err := newClient(ctx, false, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	if _, errGo := client.Gists.Unstar(ctx, id); errGo != nil {
+		reportGithubErr(errGo, "gists/"+id+"/star")
+		return
+	}
+}
+
+// cmdFork implements "gister fork <gist-id>".
+func cmdFork(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("fork", flag.ExitOnError)
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) != 1 {
+		log.Fatal("Error: usage is gister fork <gist-id>.")
+	}
+	id := rest[0]
+
+	client, err := newClient(ctx, false, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	result, _, errGo := client.Gists.Fork(ctx, id)
+	if errGo != nil {
+		reportGithubErr(errGo, "gists/"+id+"/forks")
+		return
+	}
+	fmt.Println(result.GetHTMLURL())
+}
+
+// cmdComment implements "gister comment [-list] <gist-id> [text]". With
+// -list it prints existing comments via ListComments, otherwise the
+// remaining arguments are joined and posted with CreateComment.
+func cmdComment(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("comment", flag.ExitOnError)
+	list := fs.Bool("list", false, "List existing comments instead of creating one.")
+	endpoint := addEndpointFlag(fs)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	rest := fs.Args()
+	if len(rest) == 0 {
+		log.Fatal("Error: usage is gister comment [-list] <gist-id> [text].")
+	}
+	id, rest := rest[0], rest[1:]
+
+	client, err := newClient(ctx, false, *endpoint)
+	if err != nil {
+		logger.Fatal(err.Error())
+	}
+
+	if *list {
+		comments, _, errGo := client.Gists.ListComments(ctx, id, nil)
+		if errGo != nil {
+			reportGithubErr(errGo, "gists/"+id+"/comments")
+			return
+		}
+		for _, comment := range comments {
+			fmt.Printf("%s\t%s\t%s\n", comment.GetUser().GetLogin(), comment.GetCreatedAt(), comment.GetBody())
 		}
-		req.SetBasicAuth(words[0], words[1])
+		return
+	}
+
+	if len(rest) == 0 {
+		log.Fatal("Error: comment text is required, e.g. gister comment <gist-id> \"text\".")
 	}
 
-	logger.Debug("Uploading...")
-	client := http.Client{}
-	response, errGo := client.Do(req)
+	comment := &github.GistComment{Body: github.String(strings.Join(rest, " "))}
+	result, _, errGo := client.Gists.CreateComment(ctx, id, comment)
 	if errGo != nil {
-		logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
+		reportGithubErr(errGo, "gists/"+id+"/comments")
+		return
 	}
-	defer response.Body.Close()
+	fmt.Println(result.GetID())
+}
 
-	if errGo = json.NewDecoder(response.Body).Decode(&responseObj); errGo != nil {
+// deviceCode is the response to a device authorization request.
+type deviceCode struct {
+	DeviceCode      string `json:"device_code"`
+	UserCode        string `json:"user_code"`
+	VerificationURI string `json:"verification_uri"`
+	ExpiresIn       int    `json:"expires_in"`
+	Interval        int    `json:"interval"`
+}
+
+// deviceAccessToken is the response polled for while waiting on the user to
+// authorize a device code; Error is one of "authorization_pending",
+// "slow_down" or "expired_token" until the token is granted.
+type deviceAccessToken struct {
+	AccessToken string `json:"access_token"`
+	Error       string `json:"error"`
+}
+
+// cmdLogin implements "gister login", walking the user through the GitHub
+// OAuth Device Authorization Flow so they never have to paste a PAT.
+func cmdLogin(ctx context.Context, args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	fs.Usage = func() { usage(fs) }
+	fs.Parse(args)
+
+	clientID := os.Getenv("GISTER_GITHUB_CLIENT_ID")
+	if len(clientID) == 0 {
+		log.Fatal("Error: GISTER_GITHUB_CLIENT_ID must be set to the OAuth App client id registered for device flow login.")
+	}
+
+	device, errGo := requestDeviceCode(ctx, clientID)
+	if errGo != nil {
 		logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
 	}
 
-	if _, ok := responseObj["html_url"]; !ok {
-		if a, ok := responseObj["errors"]; ok {
-			for i, m := range a.([]interface{}) {
-				for k, v := range m.(map[string]interface{}) {
-					logger.Error(fmt.Sprintf("%d %s: %s\n", i, k, v))
-				}
+	fmt.Printf("Go to %s and enter code: %s\n", device.VerificationURI, device.UserCode)
+
+	interval := time.Duration(device.Interval) * time.Second
+	if interval == 0 {
+		interval = 5 * time.Second
+	}
+	deadline := time.Now().Add(time.Duration(device.ExpiresIn) * time.Second)
+
+	for time.Now().Before(deadline) {
+		time.Sleep(interval)
+
+		token, errGo := pollDeviceToken(ctx, clientID, device.DeviceCode)
+		if errGo != nil {
+			switch errGo.Error() {
+			case "authorization_pending":
+				continue
+			case "slow_down":
+				interval += 5 * time.Second
+				continue
+			default:
+				logger.Fatal(errors.Wrap(errGo).With("stack", stack.Trace().TrimRuntime()).Error())
 			}
 		}
-		logger.Error(responseObj["message"].(string), "url", base.ResolveReference(urlPath).String())
+
+		if err := storeToken(token); err != nil {
+			logger.Fatal(err.Error())
+		}
+		fmt.Println("Login succeeded, token stored.")
+		return
+	}
+
+	log.Fatal("Error: device login timed out waiting for authorization.")
+}
+
+// requestDeviceCode starts the device flow by asking GitHub for a user code
+// and verification URI to present to the user.
+func requestDeviceCode(ctx context.Context, clientID string) (device *deviceCode, errGo error) {
+	form := url.Values{"client_id": {clientID}, "scope": {"gist"}}
+
+	req, errGo := http.NewRequestWithContext(ctx, "POST", "https://github.com/login/device/code", strings.NewReader(form.Encode()))
+	if errGo != nil {
+		return nil, errGo
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", USER_AGENT)
+
+	response, errGo := http.DefaultClient.Do(req)
+	if errGo != nil {
+		return nil, errGo
+	}
+	defer response.Body.Close()
+
+	device = &deviceCode{}
+	if errGo = json.NewDecoder(response.Body).Decode(device); errGo != nil {
+		return nil, errGo
+	}
+	return device, nil
+}
+
+// pollDeviceToken makes a single poll of the token endpoint for a pending
+// device code, returning the "authorization_pending"/"slow_down" error
+// strings verbatim so the caller can decide whether to keep polling.
+func pollDeviceToken(ctx context.Context, clientID, deviceCode string) (token string, errGo error) {
+	form := url.Values{
+		"client_id":   {clientID},
+		"device_code": {deviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+
+	req, errGo := http.NewRequestWithContext(ctx, "POST", "https://github.com/login/oauth/access_token", strings.NewReader(form.Encode()))
+	if errGo != nil {
+		return "", errGo
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", USER_AGENT)
+
+	response, errGo := http.DefaultClient.Do(req)
+	if errGo != nil {
+		return "", errGo
+	}
+	defer response.Body.Close()
+
+	result := &deviceAccessToken{}
+	if errGo = json.NewDecoder(response.Body).Decode(result); errGo != nil {
+		return "", errGo
+	}
+	if len(result.Error) != 0 {
+		return "", stderrors.New(result.Error)
 	}
+	return result.AccessToken, nil
 }
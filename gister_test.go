@@ -0,0 +1,39 @@
+package main
+
+import "testing"
+
+func TestListAnonymous(t *testing.T) {
+	cases := []struct {
+		name string
+		rest []string
+		want bool
+	}{
+		{"no username lists the authenticated user, needs a token", nil, false},
+		{"a username lists that user's public gists, no token needed", []string{"octocat"}, true},
+	}
+
+	for _, c := range cases {
+		if got := listAnonymous(c.rest); got != c.want {
+			t.Errorf("%s: listAnonymous(%v) = %v, want %v", c.name, c.rest, got, c.want)
+		}
+	}
+}
+
+func TestParseEndpointLines(t *testing.T) {
+	cases := []struct {
+		name  string
+		lines []string
+		want  string
+	}{
+		{"no lines after the token, no override", nil, ""},
+		{"bare URL on the line after the token", []string{"https://ghe.example.com/api/v3/"}, "https://ghe.example.com/api/v3/"},
+		{"key=value form", []string{"endpoint=https://ghe.example.com/api/v3/"}, "https://ghe.example.com/api/v3/"},
+		{"key=value takes priority over a later bare line", []string{"endpoint=https://ghe.example.com/api/v3/", "ignored"}, "https://ghe.example.com/api/v3/"},
+	}
+
+	for _, c := range cases {
+		if got := parseEndpointLines(c.lines); got != c.want {
+			t.Errorf("%s: parseEndpointLines(%v) = %q, want %q", c.name, c.lines, got, c.want)
+		}
+	}
+}